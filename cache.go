@@ -0,0 +1,116 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// A cacheEntry is the on-disk representation of a previous check for one
+// domain, keyed by FQDN under the cache directory.
+type cacheEntry struct {
+	Timestamp time.Time         `json:"timestamp"`
+	Server    string            `json:"server"`
+	RawText   string            `json:"raw_text"`
+	Fields    map[string]string `json:"fields"`
+	Available bool              `json:"available"`
+}
+
+// A Cache persists whois results to one JSON file per domain under dir, so
+// repeated runs over the same wordlist don't re-hit rate-limited registries.
+type Cache struct {
+	dir string
+	ttl time.Duration
+}
+
+// NewCache opens (creating if necessary) the on-disk cache directory, using
+// $XDG_CACHE_HOME/chkdomain (or os.UserCacheDir()'s chkdomain subdirectory
+// if unset) and the given TTL for freshness.
+func NewCache(ttl time.Duration) (*Cache, error) {
+	dir, err := cacheDir()
+	if err != nil {
+		return nil, fmt.Errorf("error resolving cache dir: %v", err)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("error creating cache dir %v: %v", dir, err)
+	}
+	return &Cache{dir: dir, ttl: ttl}, nil
+}
+
+// cacheDir answers the chkdomain cache directory, honoring
+// $XDG_CACHE_HOME when set.
+func cacheDir() (string, error) {
+	if xdg := os.Getenv("XDG_CACHE_HOME"); xdg != "" {
+		return filepath.Join(xdg, "chkdomain"), nil
+	}
+	base, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(base, "chkdomain"), nil
+}
+
+// Get reads the cached entry for domain, returning (entry, true) if one
+// exists and hasn't exceeded the cache's TTL, or (cacheEntry{}, false)
+// otherwise. domain must already be a validated FQDN (see isDomainValid);
+// this is also enforced here since the cache file path is derived from it.
+func (cache *Cache) Get(domain string) (cacheEntry, bool) {
+	if !isDomainValid(domain) {
+		return cacheEntry{}, false
+	}
+
+	data, err := os.ReadFile(cache.path(domain))
+	if err != nil {
+		return cacheEntry{}, false
+	}
+
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return cacheEntry{}, false
+	}
+	if cache.ttl > 0 && time.Since(entry.Timestamp) > cache.ttl {
+		return cacheEntry{}, false
+	}
+	return entry, true
+}
+
+// Put writes entry to the cache for domain, overwriting any previous entry.
+// The write goes to a temp file that's then renamed into place, so
+// concurrent Puts for the same domain (e.g. a wordlist with duplicate or
+// colliding generated entries) can't interleave and leave a truncated file.
+func (cache *Cache) Put(domain string, entry cacheEntry) error {
+	if !isDomainValid(domain) {
+		return fmt.Errorf("invalid domain: %s", domain)
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	path := cache.path(domain)
+	tmp, err := os.CreateTemp(cache.dir, strings.ToLower(domain)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// path answers the cache file path for domain. Domains are lowercased and,
+// once validated by isDomainValid (required of every Get/Put caller),
+// can't contain path separators, so the FQDN is also a safe file name.
+func (cache *Cache) path(domain string) string {
+	return filepath.Join(cache.dir, strings.ToLower(domain)+".json")
+}
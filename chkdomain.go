@@ -7,7 +7,6 @@ import (
 	"flag"
 	"fmt"
 	"io"
-	"net"
 	"os"
 	"regexp"
 	"strings"
@@ -19,109 +18,176 @@ var (
 	availableRE = regexp.MustCompile(`\b(is not registered|is available|no match for|not found)\b`)
 	// Regex for validating a domain, to prevent things like '--foo' from being queried
 	domainRE = regexp.MustCompile(`^[a-zA-Z0-9](?:[a-zA-Z0-9-\.]{0,61}[a-zA-Z0-9])?\.[a-zA-Z]{2,}$`)
-	// Time in ms past epoch when program started
-	startMS = time.Now().UnixNano() / int64(time.Millisecond)
 )
 
+// Default DNS resolver used for the dnsCheck fast path.
+const defaultResolver = "8.8.8.8:53"
+
 // The result of a whois check for a single domain.
 type Result struct {
 	domain    string
-	output    string
+	record    WhoisRecord
 	available bool
+	elapsedMS int64
 	err       error
 }
 
-// Print result of a whois check, optionally including extra debug info.
-func (result Result) Print(debug bool) {
+// Print result of a whois check to w, optionally including extra debug info
+// (timing, and registrar/expiry/nameservers parsed from the whois record).
+// This is the textFormatter's implementation.
+func (result Result) Print(w io.Writer, debug bool) {
 	if result.err != nil {
-		fmt.Printf("%s\n", result.err)
+		fmt.Fprintf(w, "%s\n", result.err)
 		return
 	}
 	if debug {
-		fmt.Printf("[%d]\t", (time.Now().UnixNano()/int64(time.Millisecond))-startMS)
+		fmt.Fprintf(w, "[%d]\t", result.elapsedMS)
 	}
 	if result.available {
 		if debug {
-			fmt.Printf("AVAILABLE\t")
+			fmt.Fprintf(w, "AVAILABLE\t")
 		}
-		fmt.Println(result.domain)
+		fmt.Fprintln(w, result.domain)
 	} else {
 		if debug {
-			fmt.Printf("UNAVAILABLE\t")
-			fmt.Println(result.domain)
+			fmt.Fprintf(w, "UNAVAILABLE\t")
+			fmt.Fprintln(w, result.domain)
 		}
 	}
+	if debug {
+		result.printDebugFields(w)
+	}
 }
 
-// A currently running job to lookup info for a single domain.
-type Job struct {
-	domain  string
-	results chan<- Result
-}
-
-// Run job and send result to 'results' channel.
-func (job Job) Run() {
-	result := Result{domain: job.domain}
-	if whoisOutput, err := whois(result.domain); err != nil {
-		result.err = err
-	} else {
-		result.output = whoisOutput
-		result.available = isDomainAvailable(whoisOutput)
+// Print the subset of parsed whois fields useful for debugging, indented
+// under the domain's result line.
+func (result Result) printDebugFields(w io.Writer) {
+	for _, key := range []string{"registrar", "registry expiry date", "expiration date", "name server"} {
+		if value, ok := result.record.Fields[key]; ok {
+			fmt.Fprintf(w, "\t%s: %s\n", key, value)
+		}
 	}
-	job.results <- result
 }
 
-// Answer whether domain appears to be available based on whois text result.
-func isDomainAvailable(whoisText string) bool {
-	return availableRE.FindString(strings.ToLower(whoisText)) != ""
+// A job to look up whois info for a single domain, optionally run through a
+// Pool (which provides per-server rate limiting).
+type Job struct {
+	domain string
+	pool   *Pool
 }
 
-// Answer whether domain is valid by validating against domainRE regex.
-func isDomainValid(domain string) bool {
-	return !!domainRE.MatchString(domain)
+// run consults the cache, then performs the DNS fast-path check and (when
+// needed) the whois lookup, returning the Result. It's the single code path
+// shared by the Pool's workers.
+func (job Job) run() Result {
+	start := time.Now()
+	result := job.runChecks()
+	result.elapsedMS = time.Since(start).Milliseconds()
+	return result
 }
 
-// Get the whois server (including port) for querying a given domain.
-func getWhoisServer(domain string) string {
-	segments := strings.Split(domain, ".")
-	tld := segments[len(segments)-1]
-	if len(segments) > 2 {
-		tld = segments[len(segments)-2] + "." + tld
+// runChecks implements run's cache-then-check logic, without timing.
+func (job Job) runChecks() Result {
+	if !isDomainValid(job.domain) {
+		return Result{domain: job.domain, err: fmt.Errorf("invalid domain: %s", job.domain)}
 	}
-	return tld + ".whois-servers.net:43"
-}
 
-// Run a whois check for the given domain, returning a non-empty string result
-// of the lookup (and nil) on success, or an empty string and error on failure.
-func whois(domain string) (string, error) {
-	if !isDomainValid(domain) {
-		return "", fmt.Errorf("invalid domain: %s", domain)
+	var cache *Cache
+	var refresh bool
+	if job.pool != nil {
+		cache, refresh = job.pool.cache, job.pool.refresh
 	}
-	whoisServer := getWhoisServer(domain)
 
-	conn, connErr := net.Dial("tcp4", whoisServer)
-	if connErr != nil {
-		return "", fmt.Errorf("error connecting to %v: %v", whoisServer, connErr)
+	if cache != nil {
+		if entry, ok := cache.Get(job.domain); ok {
+			// In refresh mode, only domains previously found available are
+			// worth re-checking; everything else is served from cache as-is.
+			if !refresh || !entry.Available {
+				return resultFromCacheEntry(job.domain, entry)
+			}
+		}
 	}
 
-	_, wrtErr := conn.Write([]byte(domain + "\r\n"))
-	if wrtErr != nil {
-		return "", fmt.Errorf("error writing to socket: %v", wrtErr)
+	result := job.check()
+
+	if cache != nil && result.err == nil {
+		if err := cache.Put(job.domain, cacheEntryFromResult(result)); err != nil {
+			fmt.Fprintf(os.Stderr, "error caching result for %v: %v\n", job.domain, err)
+		}
 	}
+	return result
+}
 
-	buf := make([]byte, 1024)
-	res := []byte{}
-	for {
-		numBytes, readErr := conn.Read(buf)
-		if numBytes == 0 && readErr != io.EOF {
-			return "", readErr
+// check performs the DNS fast-path check and (when needed) the whois
+// lookup for job.domain, ignoring the cache.
+func (job Job) check() Result {
+	result := Result{domain: job.domain}
+
+	resolver, dnsOnly, waitTurn := defaultResolver, false, func(server string) {}
+	if job.pool != nil {
+		resolver, dnsOnly, waitTurn = job.pool.resolver, job.pool.dnsOnly, job.pool.waitTurn
+	}
+
+	if registered, err := dnsCheck(result.domain, resolver); err == nil {
+		if registered {
+			result.available = false
+			return result
 		}
-		res = append(res, buf[0:numBytes]...)
-		if readErr == io.EOF {
-			break
+		if dnsOnly {
+			result.available = true
+			return result
 		}
 	}
-	return string(res), nil
+
+	if record, err := whois(result.domain, waitTurn); err != nil {
+		result.err = err
+	} else {
+		result.record = record
+		result.available = isDomainAvailable(record)
+	}
+	return result
+}
+
+// resultFromCacheEntry converts a cached entry back into a Result.
+func resultFromCacheEntry(domain string, entry cacheEntry) Result {
+	return Result{
+		domain:    domain,
+		available: entry.Available,
+		record:    WhoisRecord{Server: entry.Server, Fields: entry.Fields, RawText: entry.RawText},
+	}
+}
+
+// cacheEntryFromResult converts a (successful) Result into the form
+// persisted to the cache.
+func cacheEntryFromResult(result Result) cacheEntry {
+	return cacheEntry{
+		Timestamp: time.Now(),
+		Server:    result.record.Server,
+		RawText:   result.record.RawText,
+		Fields:    result.record.Fields,
+		Available: result.available,
+	}
+}
+
+// Answer whether domain appears to be available, preferring structured
+// signals from the parsed whois record (no domain/registrar fields present,
+// an explicit "status: available", or an empty record from the registry)
+// and only falling back to the raw-text regex when nothing was parsed.
+func isDomainAvailable(record WhoisRecord) bool {
+	if status, ok := record.Fields["status"]; ok && strings.Contains(strings.ToLower(status), "available") {
+		return true
+	}
+
+	hasDomainField := record.Fields["domain"] != "" || record.Fields["domain name"] != "" || record.Fields["registrar"] != ""
+	if len(record.Fields) == 0 {
+		return availableRE.FindString(strings.ToLower(record.RawText)) != ""
+	}
+	return !hasDomainField
+}
+
+// Answer whether domain is valid by validating against domainRE regex.
+func isDomainValid(domain string) bool {
+	return !!domainRE.MatchString(domain)
 }
 
 // Read whitespace-delimited words from stdin and split on space and/or newline,
@@ -156,6 +222,18 @@ func main() {
 	}
 
 	debug := flag.Bool("debug", false, "print debug info (all results, with times)")
+	concurrency := flag.Int("concurrency", 16, "number of domains to check concurrently")
+	ratePerServer := flag.Float64("rate-per-server", 1, "max whois queries per second to any one whois server")
+	retries := flag.Int("retries", 2, "number of retries on network errors or rate-limit responses, with exponential backoff")
+	resolver := flag.String("resolver", defaultResolver, "DNS resolver (host:port) used for the NS/SOA fast-path check")
+	dnsOnly := flag.Bool("dns-only", false, "trust a double-NXDOMAIN DNS result as \"available\" without confirming via whois")
+	tlds := flag.String("tlds", "", "comma-separated TLDs (e.g. com,net,io,co.uk); when set, DOMAIN arguments are bare words to expand across prefixes/suffixes/tlds instead of FQDNs")
+	prefixes := flag.String("prefixes", "", "comma-separated prefixes to prepend to each word when -tlds is set")
+	suffixes := flag.String("suffixes", "", "comma-separated suffixes to append to each word when -tlds is set")
+	cacheTTL := flag.Duration("cache-ttl", 24*time.Hour, "how long a cached whois result stays fresh")
+	noCache := flag.Bool("no-cache", false, "disable the on-disk whois cache")
+	refresh := flag.Bool("refresh", false, "only re-check domains whose cached result was \"available\"; serve everything else from cache")
+	output := flag.String("output", "text", "output format: text, json, ndjson, or csv")
 	flag.Parse()
 
 	// At least 1 arg is required, so print usage and fail if none given.
@@ -164,32 +242,54 @@ func main() {
 	}
 
 	domains := flag.Args()
-	numDomains := len(domains)
 
 	// If there's just one arg, check if it's '-' to indicate that
 	// domains will be provided one-per-line via stdin, and read them if so.
-	if numDomains == 1 && domains[0] == "-" {
+	if len(domains) == 1 && domains[0] == "-" {
 		fileDomains, err := readWords()
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "error reading domains from stdin: %v", err)
 			os.Exit(1)
 		}
 		domains = fileDomains
-		numDomains = len(domains)
 	}
 
-	// Results channel to which each job doing the lookup in a goroutine
-	// will send its result upon completion
-	results := make(chan Result, numDomains)
+	// When -tlds is given, DOMAIN arguments are bare words to expand into
+	// candidate domains rather than FQDNs to check directly.
+	if tldList := splitCSV(*tlds); len(tldList) > 0 {
+		domains = generateDomains(domains, splitCSV(*prefixes), splitCSV(*suffixes), tldList)
+	}
+
+	var cache *Cache
+	if !*noCache {
+		var err error
+		if cache, err = NewCache(*cacheTTL); err != nil {
+			fmt.Fprintf(os.Stderr, "error opening whois cache, continuing without it: %v\n", err)
+		}
+	}
 
-	// Start all coroutines
-	for _, domain := range domains {
-		go Job{domain, results}.Run()
+	formatter, err := NewFormatter(*output, os.Stdout, *debug)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
 	}
 
+	pool := NewPool(*concurrency, *retries, *ratePerServer, *resolver, *dnsOnly, cache, *refresh)
+	go func() {
+		for _, domain := range domains {
+			pool.Submit(domain)
+		}
+		pool.Close()
+	}()
+
 	// Handle result of each as it is available
-	for _ = range domains {
-		result := <-results
-		result.Print(*debug)
+	for result := range pool.Results() {
+		if err := formatter.Write(result); err != nil {
+			fmt.Fprintf(os.Stderr, "error writing result for %v: %v\n", result.domain, err)
+		}
+	}
+	if err := formatter.Close(); err != nil {
+		fmt.Fprintf(os.Stderr, "error finalizing output: %v\n", err)
+		os.Exit(1)
 	}
 }
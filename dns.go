@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// Timeout for a single DNS query in dnsCheck.
+const dnsTimeout = 5 * time.Second
+
+// dnsCheck looks up NS and SOA records for domain against resolver. If
+// either record exists, the domain is definitely registered. If both
+// queries come back NXDOMAIN, the domain is likely unregistered (but whois
+// is still the source of truth unless -dns-only was given).
+func dnsCheck(domain, resolver string) (registered bool, err error) {
+	client := &dns.Client{Timeout: dnsTimeout}
+
+	for _, qtype := range []uint16{dns.TypeNS, dns.TypeSOA} {
+		msg := new(dns.Msg)
+		msg.SetQuestion(dns.Fqdn(domain), qtype)
+
+		reply, _, err := client.Exchange(msg, resolver)
+		if err != nil {
+			return false, fmt.Errorf("error querying %v for %v: %v", resolver, domain, err)
+		}
+
+		switch reply.Rcode {
+		case dns.RcodeNameError:
+			// NXDOMAIN: a real "no record" signal, keep checking.
+			continue
+		case dns.RcodeSuccess:
+			if len(reply.Answer) > 0 || len(reply.Ns) > 0 {
+				return true, nil
+			}
+			// Empty NOERROR response: also a real "no record" signal.
+		default:
+			// SERVFAIL, REFUSED, FormErr, etc. aren't a "no record" signal,
+			// just an inconclusive resolver response - don't let -dns-only
+			// mistake this for "likely available".
+			return false, fmt.Errorf("unexpected rcode %v querying %v for %v", dns.RcodeToString[reply.Rcode], resolver, domain)
+		}
+	}
+
+	return false, nil
+}
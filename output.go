@@ -0,0 +1,155 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// csvHeader is the column order used by the CSV formatter, matching the
+// field order of outputRecord.
+var csvHeader = []string{"domain", "available", "error", "elapsed_ms", "whois_server", "registrar", "expiry", "nameservers"}
+
+// outputRecord is the structured, per-domain view of a Result used by the
+// json/ndjson/csv formatters.
+type outputRecord struct {
+	Domain      string `json:"domain"`
+	Available   bool   `json:"available"`
+	Error       string `json:"error,omitempty"`
+	ElapsedMS   int64  `json:"elapsed_ms"`
+	WhoisServer string `json:"whois_server,omitempty"`
+	Registrar   string `json:"registrar,omitempty"`
+	Expiry      string `json:"expiry,omitempty"`
+	Nameservers string `json:"nameservers,omitempty"`
+}
+
+// newOutputRecord builds the structured view of result.
+func newOutputRecord(result Result) outputRecord {
+	record := outputRecord{
+		Domain:      result.domain,
+		Available:   result.available,
+		ElapsedMS:   result.elapsedMS,
+		WhoisServer: result.record.Server,
+		Registrar:   result.record.Fields["registrar"],
+		Nameservers: result.record.Fields["name server"],
+	}
+	if result.err != nil {
+		record.Error = result.err.Error()
+	}
+	for _, key := range []string{"registry expiry date", "expiration date"} {
+		if expiry, ok := result.record.Fields[key]; ok {
+			record.Expiry = expiry
+			break
+		}
+	}
+	return record
+}
+
+// A Formatter turns Results into output as they complete. Write is called
+// once per domain in completion order; Close is called once after the last
+// Write, to let formatters that buffer (like JSON) flush their output.
+type Formatter interface {
+	Write(result Result) error
+	Close() error
+}
+
+// NewFormatter builds the Formatter for the named output kind
+// (text, json, ndjson, or csv), writing to w.
+func NewFormatter(kind string, w io.Writer, debug bool) (Formatter, error) {
+	switch kind {
+	case "", "text":
+		return &textFormatter{w: w, debug: debug}, nil
+	case "json":
+		return &jsonFormatter{w: w}, nil
+	case "ndjson":
+		return &ndjsonFormatter{w: w}, nil
+	case "csv":
+		return &csvFormatter{w: csv.NewWriter(w)}, nil
+	default:
+		return nil, fmt.Errorf("unknown output format: %s", kind)
+	}
+}
+
+// textFormatter reproduces the tool's original free-form text output.
+type textFormatter struct {
+	w     io.Writer
+	debug bool
+}
+
+func (f *textFormatter) Write(result Result) error {
+	result.Print(f.w, f.debug)
+	return nil
+}
+
+func (f *textFormatter) Close() error { return nil }
+
+// ndjsonFormatter streams one JSON object per result, as they complete.
+type ndjsonFormatter struct {
+	w io.Writer
+}
+
+func (f *ndjsonFormatter) Write(result Result) error {
+	data, err := json.Marshal(newOutputRecord(result))
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(f.w, "%s\n", data)
+	return err
+}
+
+func (f *ndjsonFormatter) Close() error { return nil }
+
+// jsonFormatter buffers every result and emits a single JSON array on
+// Close, for consumers that want one document rather than a stream.
+type jsonFormatter struct {
+	w       io.Writer
+	records []outputRecord
+}
+
+func (f *jsonFormatter) Write(result Result) error {
+	f.records = append(f.records, newOutputRecord(result))
+	return nil
+}
+
+func (f *jsonFormatter) Close() error {
+	data, err := json.MarshalIndent(f.records, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(f.w, "%s\n", data)
+	return err
+}
+
+// csvFormatter writes a header row followed by one row per result.
+type csvFormatter struct {
+	w           *csv.Writer
+	wroteHeader bool
+}
+
+func (f *csvFormatter) Write(result Result) error {
+	if !f.wroteHeader {
+		if err := f.w.Write(csvHeader); err != nil {
+			return err
+		}
+		f.wroteHeader = true
+	}
+
+	record := newOutputRecord(result)
+	row := []string{
+		record.Domain,
+		fmt.Sprintf("%t", record.Available),
+		record.Error,
+		fmt.Sprintf("%d", record.ElapsedMS),
+		record.WhoisServer,
+		record.Registrar,
+		record.Expiry,
+		record.Nameservers,
+	}
+	return f.w.Write(row)
+}
+
+func (f *csvFormatter) Close() error {
+	f.w.Flush()
+	return f.w.Error()
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func sampleResults() []Result {
+	return []Result{
+		{
+			domain:    "example.com",
+			available: false,
+			elapsedMS: 42,
+			record: WhoisRecord{
+				Server: "whois.markmonitor.com",
+				Fields: map[string]string{
+					"registrar":            "MarkMonitor Inc.",
+					"registry expiry date": "2025-08-13T04:00:00Z",
+					"name server":          "A.IANA-SERVERS.NET, B.IANA-SERVERS.NET",
+				},
+			},
+		},
+		{
+			domain:    "notarealdomain123.com",
+			available: true,
+			elapsedMS: 7,
+		},
+		{
+			domain: "broken.com",
+			err:    errors.New("error connecting to whois.nic.broken:43: timeout"),
+		},
+	}
+}
+
+func writeAll(t *testing.T, f Formatter, results []Result) {
+	t.Helper()
+	for _, result := range results {
+		if err := f.Write(result); err != nil {
+			t.Fatalf("Write(%v) failed: %v", result.domain, err)
+		}
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close() failed: %v", err)
+	}
+}
+
+func TestTextFormatter(t *testing.T) {
+	var buf bytes.Buffer
+	f, err := NewFormatter("text", &buf, false)
+	if err != nil {
+		t.Fatalf("NewFormatter failed: %v", err)
+	}
+	writeAll(t, f, sampleResults())
+
+	out := buf.String()
+	if !strings.Contains(out, "notarealdomain123.com") {
+		t.Errorf("expected available domain to be printed, got: %s", out)
+	}
+	if strings.Contains(out, "example.com") {
+		t.Errorf("unavailable domain shouldn't be printed without -debug, got: %s", out)
+	}
+	if !strings.Contains(out, "error connecting") {
+		t.Errorf("expected error to be printed, got: %s", out)
+	}
+}
+
+func TestNDJSONFormatter(t *testing.T) {
+	var buf bytes.Buffer
+	f, err := NewFormatter("ndjson", &buf, false)
+	if err != nil {
+		t.Fatalf("NewFormatter failed: %v", err)
+	}
+	results := sampleResults()
+	writeAll(t, f, results)
+
+	lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+	if len(lines) != len(results) {
+		t.Fatalf("got %d ndjson lines, want %d", len(lines), len(results))
+	}
+
+	var first outputRecord
+	if err := json.Unmarshal([]byte(lines[0]), &first); err != nil {
+		t.Fatalf("failed to unmarshal first ndjson line: %v", err)
+	}
+	if first.Domain != "example.com" || first.Registrar != "MarkMonitor Inc." {
+		t.Errorf("first record = %+v, want domain=example.com registrar=MarkMonitor Inc.", first)
+	}
+}
+
+func TestJSONFormatterBuffersUntilClose(t *testing.T) {
+	var buf bytes.Buffer
+	f, err := NewFormatter("json", &buf, false)
+	if err != nil {
+		t.Fatalf("NewFormatter failed: %v", err)
+	}
+	results := sampleResults()
+
+	if err := f.Write(results[0]); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("json formatter should buffer until Close, got output: %s", buf.String())
+	}
+
+	for _, result := range results[1:] {
+		if err := f.Write(result); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	var records []outputRecord
+	if err := json.Unmarshal(buf.Bytes(), &records); err != nil {
+		t.Fatalf("failed to unmarshal json array: %v", err)
+	}
+	if len(records) != len(results) {
+		t.Fatalf("got %d records, want %d", len(records), len(results))
+	}
+}
+
+func TestCSVFormatter(t *testing.T) {
+	var buf bytes.Buffer
+	f, err := NewFormatter("csv", &buf, false)
+	if err != nil {
+		t.Fatalf("NewFormatter failed: %v", err)
+	}
+	results := sampleResults()
+	writeAll(t, f, results)
+
+	rows, err := csv.NewReader(strings.NewReader(buf.String())).ReadAll()
+	if err != nil {
+		t.Fatalf("failed to parse csv output: %v", err)
+	}
+	if len(rows) != len(results)+1 {
+		t.Fatalf("got %d csv rows (incl. header), want %d", len(rows), len(results)+1)
+	}
+	if !reflectEqualStrings(rows[0], csvHeader) {
+		t.Errorf("csv header = %v, want %v", rows[0], csvHeader)
+	}
+	if rows[1][0] != "example.com" || rows[1][5] != "MarkMonitor Inc." {
+		t.Errorf("csv row = %v, want domain=example.com registrar=MarkMonitor Inc.", rows[1])
+	}
+}
+
+func TestNewFormatterUnknownKind(t *testing.T) {
+	if _, err := NewFormatter("xml", &bytes.Buffer{}, false); err == nil {
+		t.Errorf("expected an error for an unknown output format, got nil")
+	}
+}
+
+func reflectEqualStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
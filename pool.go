@@ -0,0 +1,147 @@
+package main
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// Strings that show up in whois responses when a registry is throttling us,
+// so retries can back off instead of hammering the server again immediately.
+var rateLimitedRE = []string{"exceeded", "quota", "try again"}
+
+// A Pool runs whois Jobs across a bounded number of worker goroutines,
+// rate-limiting queries to any one whois server and retrying failed jobs
+// with exponential backoff. It is the shared code path for both the
+// chkdomain binary and any future library consumer.
+type Pool struct {
+	concurrency   int
+	retries       int
+	ratePerServer float64
+	resolver      string
+	dnsOnly       bool
+	cache         *Cache
+	refresh       bool
+
+	jobs    chan Job
+	results chan Result
+
+	limiters   map[string]*time.Ticker
+	limitersMu sync.Mutex
+
+	wg sync.WaitGroup
+}
+
+// Create a new Pool with the given concurrency (number of worker
+// goroutines), retries (additional attempts after the first, on network
+// errors or rate-limit responses), ratePerServer (max queries per second to
+// any single whois server), resolver (DNS server used for the fast-path
+// pre-check), dnsOnly (whether a double-NXDOMAIN DNS result is enough to
+// call a domain available without confirming via whois), cache (may be nil
+// to disable caching), and refresh (in refresh mode, only domains cached as
+// available are re-checked; everything else is served straight from cache).
+func NewPool(concurrency, retries int, ratePerServer float64, resolver string, dnsOnly bool, cache *Cache, refresh bool) *Pool {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	pool := &Pool{
+		concurrency:   concurrency,
+		retries:       retries,
+		ratePerServer: ratePerServer,
+		resolver:      resolver,
+		dnsOnly:       dnsOnly,
+		cache:         cache,
+		refresh:       refresh,
+		jobs:          make(chan Job, concurrency),
+		results:       make(chan Result, concurrency),
+		limiters:      map[string]*time.Ticker{},
+	}
+	pool.wg.Add(concurrency)
+	for i := 0; i < concurrency; i++ {
+		go pool.worker()
+	}
+	return pool
+}
+
+// Submit a domain to be checked. Blocks if every worker is busy.
+func (pool *Pool) Submit(domain string) {
+	pool.jobs <- Job{domain: domain, pool: pool}
+}
+
+// Close the pool to further Submit calls, and wait for all in-flight jobs
+// to finish. Call once all domains have been submitted.
+func (pool *Pool) Close() {
+	close(pool.jobs)
+	pool.wg.Wait()
+	close(pool.results)
+}
+
+// Results returns the channel on which completed Results are delivered.
+func (pool *Pool) Results() <-chan Result {
+	return pool.results
+}
+
+// worker runs jobs off the pool's job channel until it's closed.
+func (pool *Pool) worker() {
+	defer pool.wg.Done()
+	for job := range pool.jobs {
+		pool.results <- pool.runWithRetries(job)
+	}
+}
+
+// runWithRetries runs job, retrying on network errors or rate-limit
+// responses with exponential backoff, up to pool.retries additional times.
+func (pool *Pool) runWithRetries(job Job) Result {
+	var result Result
+	for attempt := 0; ; attempt++ {
+		result = job.run()
+		if !shouldRetry(result) || attempt >= pool.retries {
+			return result
+		}
+		time.Sleep(backoff(attempt))
+	}
+}
+
+// backoff returns the exponential backoff delay before retry attempt n
+// (0-indexed): 500ms, 1s, 2s, 4s, ...
+func backoff(attempt int) time.Duration {
+	return 500 * time.Millisecond * time.Duration(1<<uint(attempt))
+}
+
+// shouldRetry answers whether result looks like a transient failure worth
+// retrying: a network error, or a whois response that reads like a
+// rate-limit message.
+func shouldRetry(result Result) bool {
+	if result.err != nil {
+		return true
+	}
+	return looksRateLimited(result.record.RawText)
+}
+
+// looksRateLimited answers whether text contains any of the phrases
+// registries commonly use to report that we've been throttled.
+func looksRateLimited(text string) bool {
+	lower := strings.ToLower(text)
+	for _, phrase := range rateLimitedRE {
+		if strings.Contains(lower, phrase) {
+			return true
+		}
+	}
+	return false
+}
+
+// waitTurn blocks until the pool's token bucket for whoisServer allows
+// another query, creating the bucket (and its ticker) on first use.
+func (pool *Pool) waitTurn(whoisServer string) {
+	if pool.ratePerServer <= 0 {
+		return
+	}
+	pool.limitersMu.Lock()
+	ticker, ok := pool.limiters[whoisServer]
+	if !ok {
+		ticker = time.NewTicker(time.Duration(float64(time.Second) / pool.ratePerServer))
+		pool.limiters[whoisServer] = ticker
+	}
+	pool.limitersMu.Unlock()
+	<-ticker.C
+}
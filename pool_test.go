@@ -0,0 +1,68 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	cases := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{0, 500 * time.Millisecond},
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{3, 4 * time.Second},
+	}
+	for _, c := range cases {
+		if got := backoff(c.attempt); got != c.want {
+			t.Errorf("backoff(%d) = %v, want %v", c.attempt, got, c.want)
+		}
+	}
+}
+
+func TestLooksRateLimited(t *testing.T) {
+	cases := map[string]bool{
+		"Query rate limit exceeded, try again later": true,
+		"WHOIS LIMIT EXCEEDED":                       true,
+		"Your query quota has been used up":          true,
+		"Domain Name: EXAMPLE.COM":                   false,
+		"":                                           false,
+	}
+	for text, want := range cases {
+		if got := looksRateLimited(text); got != want {
+			t.Errorf("looksRateLimited(%q) = %v, want %v", text, got, want)
+		}
+	}
+}
+
+func TestShouldRetry(t *testing.T) {
+	cases := []struct {
+		name   string
+		result Result
+		want   bool
+	}{
+		{
+			name:   "network error",
+			result: Result{err: errors.New("connection reset")},
+			want:   true,
+		},
+		{
+			name:   "rate-limit response",
+			result: Result{record: WhoisRecord{RawText: "Query rate limit exceeded"}},
+			want:   true,
+		},
+		{
+			name:   "normal response",
+			result: Result{record: WhoisRecord{RawText: "Domain Name: EXAMPLE.COM"}},
+			want:   false,
+		},
+	}
+	for _, c := range cases {
+		if got := shouldRetry(c.result); got != c.want {
+			t.Errorf("%s: shouldRetry() = %v, want %v", c.name, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// Server where every whois referral chain starts: IANA knows the
+	// authoritative registry server for each TLD.
+	ianaWhoisServer = "whois.iana.org:43"
+	// Maximum number of referrals to follow (iana -> registry -> registrar)
+	// before giving up, to avoid loops against misbehaving servers.
+	maxWhoisHops = 3
+	// Timeout for a single whois connection.
+	whoisTimeout = 10 * time.Second
+)
+
+// Field names, as they appear (lowercased) in whois responses, that hint at
+// a referral to another whois server.
+var referralFields = []string{"refer", "whois", "registrar whois server", "referralserver"}
+
+// A WhoisRecord is the result of following a whois referral chain for a
+// domain: the server that produced the final response, its parsed fields,
+// and the raw text collected across every hop.
+type WhoisRecord struct {
+	Server  string
+	Fields  map[string]string
+	RawText string
+}
+
+// Run a whois check for the given domain, starting at IANA and following
+// registry/registrar referrals, returning the resulting WhoisRecord on
+// success or a zero WhoisRecord and error on failure. waitTurn is called
+// with each server before it's queried, so callers can rate-limit.
+func whois(domain string, waitTurn func(server string)) (WhoisRecord, error) {
+	if !isDomainValid(domain) {
+		return WhoisRecord{}, fmt.Errorf("invalid domain: %s", domain)
+	}
+
+	record := WhoisRecord{Fields: map[string]string{}}
+	server := ianaWhoisServer
+
+	for hop := 0; hop < maxWhoisHops; hop++ {
+		query := domain
+		if server == ianaWhoisServer {
+			// IANA's root whois only indexes bare TLDs, not full domains.
+			query = tld(domain)
+		}
+
+		waitTurn(server)
+		text, err := queryWhoisServer(server, query)
+		if err != nil {
+			return record, err
+		}
+
+		record.Server = server
+		if record.RawText != "" {
+			record.RawText += "\n"
+		}
+		record.RawText += text
+
+		fields := parseWhoisFields(text)
+		for key, value := range fields {
+			record.Fields[key] = value
+		}
+
+		next := referralServer(fields)
+		if next == "" || next == server {
+			break
+		}
+		server = next
+	}
+
+	return record, nil
+}
+
+// tld answers the top-level label of domain (e.g. "com" for "google.com"),
+// which is what IANA's root whois server expects to be queried with.
+func tld(domain string) string {
+	segments := strings.Split(domain, ".")
+	return segments[len(segments)-1]
+}
+
+// Open a connection to whoisServer, send the query, and return the raw
+// text of the response.
+func queryWhoisServer(whoisServer, query string) (string, error) {
+	conn, connErr := net.DialTimeout("tcp4", whoisServer, whoisTimeout)
+	if connErr != nil {
+		return "", fmt.Errorf("error connecting to %v: %v", whoisServer, connErr)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(whoisTimeout))
+
+	_, wrtErr := conn.Write([]byte(query + "\r\n"))
+	if wrtErr != nil {
+		return "", fmt.Errorf("error writing to socket: %v", wrtErr)
+	}
+
+	buf := make([]byte, 1024)
+	res := []byte{}
+	for {
+		numBytes, readErr := conn.Read(buf)
+		if numBytes == 0 && readErr != io.EOF {
+			return "", readErr
+		}
+		res = append(res, buf[0:numBytes]...)
+		if readErr == io.EOF {
+			break
+		}
+	}
+	return string(res), nil
+}
+
+// Parse a whois response into a map of lowercased, trimmed key/value pairs,
+// splitting each line on the first ':' and skipping comments ('#'/'%') and
+// blank lines. Values longer than ~250 chars are truncated with "...".
+func parseWhoisFields(text string) map[string]string {
+	fields := map[string]string{}
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimRight(line, "\r")
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "%") {
+			continue
+		}
+
+		idx := strings.Index(line, ":")
+		if idx < 0 {
+			continue
+		}
+
+		key := strings.ToLower(strings.TrimSpace(line[:idx]))
+		value := strings.TrimSpace(line[idx+1:])
+		if key == "" || value == "" {
+			continue
+		}
+		value = truncate(value, 250)
+		// Repeated keys (e.g. multiple "Name Server:" lines) accumulate
+		// instead of overwriting, so nothing is silently dropped.
+		if existing, ok := fields[key]; ok {
+			value = existing + ", " + value
+		}
+		fields[key] = value
+	}
+	return fields
+}
+
+// truncate shortens value to at most maxRunes runes (not bytes, so it never
+// splits a multi-byte UTF-8 codepoint), appending "..." if it was cut.
+func truncate(value string, maxRunes int) string {
+	if utf8.RuneCountInString(value) <= maxRunes {
+		return value
+	}
+	runes := []rune(value)
+	return string(runes[:maxRunes]) + "..."
+}
+
+// Answer the next whois server to query, found among the known referral
+// fields (checked in order, since iana's 'refer'/'whois' take precedence
+// over a registry's 'registrar whois server'/'referralserver'), or "" if
+// none of them were present.
+func referralServer(fields map[string]string) string {
+	for _, name := range referralFields {
+		if value, ok := fields[name]; ok && value != "" {
+			return normalizeWhoisServer(value)
+		}
+	}
+	return ""
+}
+
+// Normalize a referral value (which may be a bare host, a host:port, or a
+// "whois://host" / "rwhois://host:port" URL) into a host:port suitable for
+// net.Dial, defaulting to port 43.
+func normalizeWhoisServer(server string) string {
+	server = strings.TrimSpace(server)
+	if idx := strings.Index(server, "://"); idx >= 0 {
+		server = server[idx+3:]
+	}
+	server = strings.TrimSuffix(server, "/")
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		server = server + ":43"
+	}
+	return server
+}
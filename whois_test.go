@@ -0,0 +1,177 @@
+package main
+
+import "testing"
+
+// A realistic IANA response for the "com" referral query.
+const ianaComResponse = `% IANA WHOIS server
+% for more information on IANA, visit http://www.iana.org
+% This query returned 1 object
+
+refer:        whois.verisign-grs.com
+
+domain:       COM
+
+organisation: VeriSign Global Registry Services
+address:      12061 Bluemont Way
+address:      Reston VA 20190
+address:      United States
+
+whois:        whois.verisign-grs.com
+`
+
+// A realistic VeriSign registry response, referring on to the registrar.
+const registryResponse = `   Domain Name: EXAMPLE.COM
+   Registrar WHOIS Server: whois.markmonitor.com
+   Registrar URL: http://www.markmonitor.com
+   Updated Date: 2024-08-14T07:01:31Z
+   Creation Date: 1995-08-14T04:00:00Z
+   Registry Expiry Date: 2025-08-13T04:00:00Z
+   Registrar: MarkMonitor Inc.
+   Registrar IANA ID: 292
+   Domain Status: clientDeleteProhibited https://icann.org/epp#clientDeleteProhibited
+   Name Server: A.IANA-SERVERS.NET
+   Name Server: B.IANA-SERVERS.NET
+>>> Last update of whois database: 2025-01-01T00:00:00Z <<<
+`
+
+// A registrar response for a registered domain, with no further referral.
+const registrarResponse = `Domain Name: example.com
+Registry Domain ID: 2336799_DOMAIN_COM-VRSN
+Registrar: MarkMonitor Inc.
+Registrant Organization: Example Corp
+Name Server: a.iana-servers.net
+Name Server: b.iana-servers.net
+DNSSEC: unsigned
+`
+
+// A registry "no match" response for an unregistered domain, with no
+// parseable fields at all.
+const noMatchResponse = `No match for "NOTAREALDOMAIN123.COM".`
+
+func TestParseWhoisFields(t *testing.T) {
+	fields := parseWhoisFields(registryResponse)
+
+	want := map[string]string{
+		"domain name":            "EXAMPLE.COM",
+		"registrar whois server": "whois.markmonitor.com",
+		"registrar":              "MarkMonitor Inc.",
+		"registry expiry date":   "2025-08-13T04:00:00Z",
+		"name server":            "A.IANA-SERVERS.NET, B.IANA-SERVERS.NET",
+	}
+	for key, expected := range want {
+		if got := fields[key]; got != expected {
+			t.Errorf("fields[%q] = %q, want %q", key, got, expected)
+		}
+	}
+
+	// Comment lines ('%') and the closing ">>> ..." line shouldn't produce
+	// spurious fields.
+	if _, ok := fields[""]; ok {
+		t.Errorf("expected no empty-key field, got one")
+	}
+}
+
+func TestParseWhoisFieldsIgnoresComments(t *testing.T) {
+	fields := parseWhoisFields(ianaComResponse)
+	if len(fields) == 0 {
+		t.Fatalf("expected fields to be parsed from IANA response, got none")
+	}
+	if got := fields["refer"]; got != "whois.verisign-grs.com" {
+		t.Errorf("fields[refer] = %q, want whois.verisign-grs.com", got)
+	}
+}
+
+func TestParseWhoisFieldsNoMatch(t *testing.T) {
+	fields := parseWhoisFields(noMatchResponse)
+	if len(fields) != 0 {
+		t.Errorf("expected no parseable fields in a \"no match\" response, got %v", fields)
+	}
+}
+
+func TestReferralServerPrefersIANAFields(t *testing.T) {
+	fields := parseWhoisFields(ianaComResponse)
+	if got := referralServer(fields); got != "whois.verisign-grs.com:43" {
+		t.Errorf("referralServer() = %q, want whois.verisign-grs.com:43", got)
+	}
+}
+
+func TestReferralServerRegistryToRegistrar(t *testing.T) {
+	fields := parseWhoisFields(registryResponse)
+	if got := referralServer(fields); got != "whois.markmonitor.com:43" {
+		t.Errorf("referralServer() = %q, want whois.markmonitor.com:43", got)
+	}
+}
+
+func TestReferralServerNoReferral(t *testing.T) {
+	fields := parseWhoisFields(registrarResponse)
+	if got := referralServer(fields); got != "" {
+		t.Errorf("referralServer() = %q, want \"\" (registrar response is terminal)", got)
+	}
+}
+
+func TestNormalizeWhoisServer(t *testing.T) {
+	cases := map[string]string{
+		"whois.markmonitor.com":             "whois.markmonitor.com:43",
+		"whois.markmonitor.com:43":          "whois.markmonitor.com:43",
+		"whois://whois.nic.io":              "whois.nic.io:43",
+		"rwhois://rwhois.example.net:4321/": "rwhois.example.net:4321",
+	}
+	for input, want := range cases {
+		if got := normalizeWhoisServer(input); got != want {
+			t.Errorf("normalizeWhoisServer(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestIsDomainAvailableStructuredSignals(t *testing.T) {
+	cases := []struct {
+		name      string
+		record    WhoisRecord
+		available bool
+	}{
+		{
+			name:      "registered domain with registrar field",
+			record:    WhoisRecord{Fields: parseWhoisFields(registrarResponse)},
+			available: false,
+		},
+		{
+			name:      "registry reply with explicit available status",
+			record:    WhoisRecord{Fields: map[string]string{"domain name": "FOO.COM", "status": "available"}},
+			available: true,
+		},
+		{
+			name:      "registry reply with no domain/registrar fields at all",
+			record:    WhoisRecord{Fields: map[string]string{"refer": "whois.verisign-grs.com"}},
+			available: true,
+		},
+		{
+			name:      "no structured fields parsed, falls back to raw text regex",
+			record:    WhoisRecord{Fields: map[string]string{}, RawText: noMatchResponse},
+			available: true,
+		},
+	}
+
+	for _, c := range cases {
+		if got := isDomainAvailable(c.record); got != c.available {
+			t.Errorf("%s: isDomainAvailable() = %v, want %v", c.name, got, c.available)
+		}
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	ascii := "abcdefghij"
+	if got := truncate(ascii, 5); got != "abcde..." {
+		t.Errorf("truncate(ascii) = %q, want %q", got, "abcde...")
+	}
+	if got := truncate(ascii, 100); got != ascii {
+		t.Errorf("truncate(ascii, 100) = %q, want unchanged", got)
+	}
+
+	// Multi-byte runes must not be split mid-codepoint.
+	multibyte := "日本語のレジストラ名前株式会社"
+	truncated := truncate(multibyte, 5)
+	want := string([]rune(multibyte)[:5]) + "..."
+	if truncated != want {
+		t.Errorf("truncate(multibyte) = %q, want %q", truncated, want)
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"strings"
+
+	"golang.org/x/net/idna"
+	"golang.org/x/net/publicsuffix"
+)
+
+// splitCSV splits a comma-separated flag value into its trimmed, non-empty
+// parts, returning nil if s is empty.
+func splitCSV(s string) []string {
+	if s == "" {
+		return nil
+	}
+	parts := []string{}
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return parts
+}
+
+// generateDomains expands each bare word across the Cartesian product of
+// prefixes x word x suffixes x tlds, converting each label to punycode via
+// IDNA and keeping only combinations whose tld is actually the registrable
+// public suffix of the resulting domain (so e.g. "foo"+"co.uk" is kept but
+// a tld of "uk" given a word that isn't itself under .uk is dropped).
+func generateDomains(words, prefixes, suffixes, tlds []string) []string {
+	if len(prefixes) == 0 {
+		prefixes = []string{""}
+	}
+	if len(suffixes) == 0 {
+		suffixes = []string{""}
+	}
+
+	domains := []string{}
+	for _, word := range words {
+		for _, prefix := range prefixes {
+			for _, suffix := range suffixes {
+				label, err := idna.ToASCII(prefix + word + suffix)
+				if err != nil || label == "" {
+					continue
+				}
+				for _, tld := range tlds {
+					if candidate := label + "." + tld; isRegisteredUnderTLD(candidate, tld) {
+						domains = append(domains, candidate)
+					}
+				}
+			}
+		}
+	}
+	return domains
+}
+
+// isRegisteredUnderTLD answers whether tld is the actual public suffix of
+// candidate, so that e.g. a -tlds value of "uk" doesn't silently swallow
+// domains that should have been generated as "*.co.uk" instead.
+func isRegisteredUnderTLD(candidate, tld string) bool {
+	suffix, icann := publicsuffix.PublicSuffix(candidate)
+	return icann && suffix == tld
+}
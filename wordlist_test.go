@@ -0,0 +1,68 @@
+package main
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestSplitCSV(t *testing.T) {
+	cases := map[string][]string{
+		"":                nil,
+		"com":             {"com"},
+		"com,net,io":      {"com", "net", "io"},
+		" com , net ,io ": {"com", "net", "io"},
+		"com,,net":        {"com", "net"},
+	}
+	for input, want := range cases {
+		if got := splitCSV(input); !reflect.DeepEqual(got, want) {
+			t.Errorf("splitCSV(%q) = %v, want %v", input, got, want)
+		}
+	}
+}
+
+func TestIsRegisteredUnderTLD(t *testing.T) {
+	cases := []struct {
+		candidate string
+		tld       string
+		want      bool
+	}{
+		{"foo.com", "com", true},
+		{"foo.co.uk", "co.uk", true},
+		{"foo.co.uk", "uk", false},
+		{"foo.uk", "uk", true},
+	}
+	for _, c := range cases {
+		if got := isRegisteredUnderTLD(c.candidate, c.tld); got != c.want {
+			t.Errorf("isRegisteredUnderTLD(%q, %q) = %v, want %v", c.candidate, c.tld, got, c.want)
+		}
+	}
+}
+
+func TestGenerateDomains(t *testing.T) {
+	got := generateDomains([]string{"acme"}, []string{"get"}, []string{"app"}, []string{"com", "co.uk"})
+	sort.Strings(got)
+
+	want := []string{"getacmeapp.co.uk", "getacmeapp.com"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("generateDomains() = %v, want %v", got, want)
+	}
+}
+
+func TestGenerateDomainsRejectsMismatchedTLD(t *testing.T) {
+	// "foo.co" + tld "uk" forms "foo.co.uk", whose real public suffix is
+	// "co.uk", not the requested "uk" - it should be dropped rather than
+	// silently queried as if "uk" were the correct eTLD.
+	got := generateDomains([]string{"foo.co"}, nil, nil, []string{"uk"})
+	if len(got) != 0 {
+		t.Errorf("generateDomains() = %v, want none (foo.co.uk's real public suffix is co.uk, not uk)", got)
+	}
+}
+
+func TestGenerateDomainsIDNA(t *testing.T) {
+	got := generateDomains([]string{"münchen"}, nil, nil, []string{"de"})
+	want := []string{"xn--mnchen-3ya.de"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("generateDomains() = %v, want %v", got, want)
+	}
+}